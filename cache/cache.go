@@ -0,0 +1,54 @@
+// Package cache provides a pluggable read/write cache for per-project git
+// object packs, so CI runners (or repeat local syncs) can share warm data
+// instead of re-fetching it over sshfs or the network every time.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// Info describes a cached blob.
+type Info struct {
+	Size int64
+}
+
+// Backend is a minimal object-store abstraction a cache.FromURL selects an
+// implementation of. Implementations must be safe for concurrent use.
+type Backend interface {
+	// Get returns a reader for key. On a cache miss the file backend returns
+	// an error satisfying os.IsNotExist; the S3 and GCS backends return
+	// their SDK's own not-found error.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Put stores the contents of r under key, overwriting any existing blob.
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Stat reports the size of key without reading it; on a cache miss it
+	// returns the same kind of error as Get.
+	Stat(ctx context.Context, key string) (Info, error)
+}
+
+// FromURL selects a Backend implementation by addr's scheme, mirroring the
+// srpmproc storage-address convention:
+//
+//	s3://bucket/prefix   -> S3
+//	gs://bucket/prefix   -> GCS
+//	file:///var/cache/x  -> local filesystem
+func FromURL(addr string) (Backend, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cache address %q: %w", addr, err)
+	}
+
+	switch u.Scheme {
+	case "s3":
+		return newS3Backend(u)
+	case "gs":
+		return newGCSBackend(u)
+	case "file":
+		return newFileBackend(u.Path)
+	default:
+		return nil, fmt.Errorf("unsupported cache scheme %q", u.Scheme)
+	}
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// fileBackend is a Backend backed by a local directory tree, one file per
+// key (with "/" in a key creating subdirectories).
+type fileBackend struct {
+	root string
+}
+
+func newFileBackend(root string) (Backend, error) {
+	if root == "" {
+		return nil, fmt.Errorf("file cache requires a non-empty path")
+	}
+
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache dir %q: %w", root, err)
+	}
+
+	return &fileBackend{root: root}, nil
+}
+
+func (b *fileBackend) path(key string) string {
+	return filepath.Join(b.root, filepath.FromSlash(key))
+}
+
+func (b *fileBackend) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	f, err := os.Open(b.path(key))
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (b *fileBackend) Put(_ context.Context, key string, r io.Reader) error {
+	dest := b.path(key)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache dir: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".cache-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close cache file: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), dest)
+}
+
+func (b *fileBackend) Stat(_ context.Context, key string) (Info, error) {
+	fi, err := os.Stat(b.path(key))
+	if err != nil {
+		return Info{}, err
+	}
+
+	return Info{Size: fi.Size()}, nil
+}
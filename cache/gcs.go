@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"cloud.google.com/go/storage"
+)
+
+// gcsBackend is a Backend backed by a Google Cloud Storage bucket.
+type gcsBackend struct {
+	client *storage.Client
+	bucket string
+	prefix string
+}
+
+func newGCSBackend(u *url.URL) (Backend, error) {
+	client, err := storage.NewClient(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCS client: %w", err)
+	}
+
+	return &gcsBackend{
+		client: client,
+		bucket: u.Host,
+		prefix: strings.TrimPrefix(u.Path, "/"),
+	}, nil
+}
+
+func (b *gcsBackend) object(key string) *storage.ObjectHandle {
+	name := key
+	if b.prefix != "" {
+		name = b.prefix + "/" + key
+	}
+
+	return b.client.Bucket(b.bucket).Object(name)
+}
+
+func (b *gcsBackend) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return b.object(key).NewReader(ctx)
+}
+
+func (b *gcsBackend) Put(ctx context.Context, key string, r io.Reader) error {
+	w := b.object(key).NewWriter(ctx)
+
+	if _, err := io.Copy(w, r); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("failed to write GCS object: %w", err)
+	}
+
+	return w.Close()
+}
+
+func (b *gcsBackend) Stat(ctx context.Context, key string) (Info, error) {
+	attrs, err := b.object(key).Attrs(ctx)
+	if err != nil {
+		return Info{}, err
+	}
+
+	return Info{Size: attrs.Size}, nil
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// s3Backend is a Backend backed by an S3 (or S3-compatible) bucket.
+type s3Backend struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+func newS3Backend(u *url.URL) (Backend, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	return &s3Backend{
+		client: s3.NewFromConfig(cfg),
+		bucket: u.Host,
+		prefix: strings.TrimPrefix(u.Path, "/"),
+	}, nil
+}
+
+func (b *s3Backend) key(key string) string {
+	if b.prefix == "" {
+		return key
+	}
+
+	return b.prefix + "/" + key
+}
+
+func (b *s3Backend) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := b.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.key(key)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.Body, nil
+}
+
+func (b *s3Backend) Put(ctx context.Context, key string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to buffer cache payload: %w", err)
+	}
+
+	_, err = b.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.key(key)),
+		Body:   bytes.NewReader(data),
+	})
+
+	return err
+}
+
+func (b *s3Backend) Stat(ctx context.Context, key string) (Info, error) {
+	out, err := b.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.key(key)),
+	})
+	if err != nil {
+		return Info{}, err
+	}
+
+	return Info{Size: aws.ToInt64(out.ContentLength)}, nil
+}
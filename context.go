@@ -0,0 +1,30 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+
+	"github.com/repo-scm/repo/manifest"
+)
+
+type ctxKey int
+
+const manifestCtxKey ctxKey = iota
+
+// manifestContext is the result of loadManifest, stashed on a subcommand's
+// context by rootCmd's PersistentPreRunE so every subcommand other than
+// mount and unmount sees the same merged manifest without re-parsing it.
+type manifestContext struct {
+	Manifest   *manifest.Manifest
+	Provenance manifest.Provenance
+}
+
+func withManifestContext(ctx context.Context, mc *manifestContext) context.Context {
+	return context.WithValue(ctx, manifestCtxKey, mc)
+}
+
+func manifestFromContext(ctx context.Context) (*manifestContext, bool) {
+	mc, ok := ctx.Value(manifestCtxKey).(*manifestContext)
+	return mc, ok
+}
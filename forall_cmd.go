@@ -0,0 +1,76 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var forallCommand string
+
+var forallCmd = &cobra.Command{
+	Use:   "forall [-c command] [-- args...]",
+	Short: "run a shell command in every project's checkout",
+	Args:  cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runForall(cmd.Context(), args)
+	},
+}
+
+// nolint:gochecknoinits
+func initForallCmd() {
+	forallCmd.Flags().StringVarP(&forallCommand, "command", "c", "", "shell command to run (overrides positional args)")
+	rootCmd.AddCommand(forallCmd)
+}
+
+// runForall runs shellCmd in every selected project's checkout, exporting
+// REPO_PROJECT and REPO_PATH so the command can identify which project it is
+// running in.
+func runForall(ctx context.Context, args []string) error {
+	mc, ok := manifestFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("no manifest loaded; pass --manifest")
+	}
+
+	shellCmd := forallCommand
+	if shellCmd == "" {
+		shellCmd = strings.Join(args, " ")
+	}
+	if shellCmd == "" {
+		return fmt.Errorf("forall requires a command, via -c or as arguments")
+	}
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range mc.Manifest.FilteredProjects(groupsSpec) {
+		dir := p.Path
+		if dir == "" {
+			dir = p.Name
+		}
+		dir = filepath.Join(workDir, dir)
+
+		fmt.Fprintf(os.Stderr, "project %s\n", p.Name)
+
+		c := exec.CommandContext(ctx, "sh", "-c", shellCmd)
+		c.Dir = dir
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		c.Env = append(os.Environ(), "REPO_PROJECT="+p.Name, "REPO_PATH="+dir)
+
+		if err := c.Run(); err != nil {
+			return fmt.Errorf("forall failed in %q: %w", p.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,241 @@
+// Package hooks runs a manifest's <repo-hooks> scripts around sync and
+// upload, gated by trust-on-first-use approval of the script content.
+package hooks
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/repo-scm/repo/manifest"
+)
+
+// Event names matching the events the repo tool fires repo-hooks for.
+const (
+	EventPreUpload    = "pre-upload"
+	EventPostSync     = "post-sync"
+	EventPostCheckout = "post-checkout"
+)
+
+// trustFile is the default name of the trust-on-first-use store, relative to
+// the checkout root's .repo directory.
+const trustFile = "hooks_trust.json"
+
+// Prompter asks the user whether to trust a hook script; it returns true to
+// approve. The default Prompter reads a y/N answer from stdin.
+type Prompter func(question string) bool
+
+// Executor runs repo-hooks scripts declared in a manifest.
+type Executor struct {
+	// Root is the checkout root that RepoHook.InProject paths are resolved
+	// against.
+	Root string
+	// NoVerify skips all hooks unconditionally.
+	NoVerify bool
+	// Verify forces every hook to run, even if its event is not in
+	// RepoHook.EnabledList.
+	Verify bool
+	// Prompter asks for trust approval; defaults to a stdin y/N prompt.
+	Prompter Prompter
+	// Logger receives hook stdout/stderr lines; defaults to os.Stderr.
+	Logger io.Writer
+}
+
+// NewExecutor builds an Executor rooted at root.
+func NewExecutor(root string) *Executor {
+	return &Executor{Root: root}
+}
+
+// Context carries the REPO_* environment variables passed to hook scripts.
+type Context struct {
+	ProjectList string // REPO_PROJECT_LIST
+	ManifestURL string // REPO_MANIFEST_URL
+	RemoteRev   string // REPO_RREV
+	LocalRev    string // REPO_LREV
+}
+
+// Run fires event for every RepoHook in m whose EnabledList contains event
+// (or unconditionally when e.Verify is set), unless e.NoVerify is set.
+func (e *Executor) Run(ctx context.Context, event string, m *manifest.Manifest, hc Context) error {
+	if e.NoVerify {
+		return nil
+	}
+
+	projects := indexProjects(m.Project)
+
+	for _, rh := range m.RepoHook {
+		if !e.Verify && !enabledFor(rh.EnabledList, event) {
+			continue
+		}
+
+		project, ok := projects[rh.InProject]
+		if !ok {
+			return fmt.Errorf("repo-hooks in-project %q not found in manifest", rh.InProject)
+		}
+
+		script := filepath.Join(e.Root, project.Path, "hooks", event)
+		if _, err := os.Stat(script); os.IsNotExist(err) {
+			continue
+		}
+
+		if err := e.runScript(ctx, script, event, hc); err != nil {
+			return errors.Wrapf(err, "hook %q (project %q)", event, rh.InProject)
+		}
+	}
+
+	return nil
+}
+
+func (e *Executor) runScript(ctx context.Context, script, event string, hc Context) error {
+	trusted, err := e.ensureTrusted(script)
+	if err != nil {
+		return err
+	}
+	if !trusted {
+		return fmt.Errorf("hook script %q is not trusted", script)
+	}
+
+	cmd := exec.CommandContext(ctx, script)
+	cmd.Env = append(os.Environ(),
+		"REPO_PROJECT_LIST="+hc.ProjectList,
+		"REPO_MANIFEST_URL="+hc.ManifestURL,
+		"REPO_RREV="+hc.RemoteRev,
+		"REPO_LREV="+hc.LocalRev,
+	)
+
+	logger := e.Logger
+	if logger == nil {
+		logger = os.Stderr
+	}
+	cmd.Stdout = logger
+	cmd.Stderr = logger
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("hook %q failed: %w", event, err)
+	}
+
+	return nil
+}
+
+// ensureTrusted hashes script and compares it against the stored approval
+// for that path, prompting (and recording a new approval) when the hash is
+// missing or has changed since it was last approved.
+func (e *Executor) ensureTrusted(script string) (bool, error) {
+	hash, err := hashFile(script)
+	if err != nil {
+		return false, fmt.Errorf("failed to hash hook script: %w", err)
+	}
+
+	store, err := e.loadTrust()
+	if err != nil {
+		return false, err
+	}
+
+	if store[script] == hash {
+		return true, nil
+	}
+
+	prompt := e.Prompter
+	if prompt == nil {
+		prompt = stdinPrompter
+	}
+
+	if !prompt(fmt.Sprintf("Trust hook script %q (sha256:%s)?", script, hash)) {
+		return false, nil
+	}
+
+	store[script] = hash
+
+	return true, e.saveTrust(store)
+}
+
+func (e *Executor) trustPath() string {
+	return filepath.Join(e.Root, ".repo", trustFile)
+}
+
+func (e *Executor) loadTrust() (map[string]string, error) {
+	data, err := os.ReadFile(e.trustPath())
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hook trust store: %w", err)
+	}
+
+	var store map[string]string
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("failed to parse hook trust store: %w", err)
+	}
+
+	return store, nil
+}
+
+func (e *Executor) saveTrust(store map[string]string) error {
+	if err := os.MkdirAll(filepath.Dir(e.trustPath()), 0o755); err != nil {
+		return fmt.Errorf("failed to create .repo dir: %w", err)
+	}
+
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode hook trust store: %w", err)
+	}
+
+	return os.WriteFile(e.trustPath(), data, 0o644)
+}
+
+func indexProjects(projects []manifest.Project) map[string]manifest.Project {
+	out := make(map[string]manifest.Project, len(projects))
+	for _, p := range projects {
+		out[p.Name] = p
+	}
+
+	return out
+}
+
+func enabledFor(enabledList, event string) bool {
+	for _, e := range strings.Split(enabledList, ",") {
+		if strings.TrimSpace(e) == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func stdinPrompter(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		return false
+	}
+
+	answer := strings.ToLower(strings.TrimSpace(scanner.Text()))
+
+	return answer == "y" || answer == "yes"
+}
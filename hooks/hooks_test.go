@@ -0,0 +1,154 @@
+package hooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/repo-scm/repo/manifest"
+)
+
+func writeHookScript(t *testing.T, root, projectPath, event, body string) string {
+	t.Helper()
+
+	dir := filepath.Join(root, projectPath, "hooks")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+
+	script := filepath.Join(dir, event)
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	return script
+}
+
+func manifestWithHook(event string) *manifest.Manifest {
+	return &manifest.Manifest{
+		Project:  []manifest.Project{{Name: "proj", Path: "proj"}},
+		RepoHook: []manifest.RepoHook{{InProject: "proj", EnabledList: event}},
+	}
+}
+
+func TestEnsureTrustedPromptsOnceThenReusesApproval(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts are executed directly; unix-only")
+	}
+
+	root := t.TempDir()
+	writeHookScript(t, root, "proj", EventPostSync, "#!/bin/sh\nexit 0\n")
+
+	e := NewExecutor(root)
+
+	prompts := 0
+	e.Prompter = func(string) bool {
+		prompts++
+		return true
+	}
+
+	m := manifestWithHook(EventPostSync)
+
+	if err := e.Run(context.Background(), EventPostSync, m, Context{}); err != nil {
+		t.Fatalf("Run (first, prompts): %v", err)
+	}
+	if prompts != 1 {
+		t.Fatalf("prompts after first run = %d, want 1", prompts)
+	}
+
+	if err := e.Run(context.Background(), EventPostSync, m, Context{}); err != nil {
+		t.Fatalf("Run (second, should reuse trust): %v", err)
+	}
+	if prompts != 1 {
+		t.Fatalf("prompts after second run = %d, want 1 (trust should be cached)", prompts)
+	}
+}
+
+func TestEnsureTrustedRepromptsOnScriptChange(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts are executed directly; unix-only")
+	}
+
+	root := t.TempDir()
+	script := writeHookScript(t, root, "proj", EventPostSync, "#!/bin/sh\nexit 0\n")
+
+	e := NewExecutor(root)
+
+	prompts := 0
+	e.Prompter = func(string) bool {
+		prompts++
+		return true
+	}
+
+	m := manifestWithHook(EventPostSync)
+
+	if err := e.Run(context.Background(), EventPostSync, m, Context{}); err != nil {
+		t.Fatalf("Run (first): %v", err)
+	}
+	if prompts != 1 {
+		t.Fatalf("prompts after first run = %d, want 1", prompts)
+	}
+
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho changed\nexit 0\n"), 0o755); err != nil {
+		t.Fatalf("WriteFile (modify script): %v", err)
+	}
+
+	if err := e.Run(context.Background(), EventPostSync, m, Context{}); err != nil {
+		t.Fatalf("Run (after script change): %v", err)
+	}
+	if prompts != 2 {
+		t.Fatalf("prompts after script change = %d, want 2 (hash changed, trust invalidated)", prompts)
+	}
+}
+
+func TestEnsureTrustedFailsClosedWhenDeclined(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts are executed directly; unix-only")
+	}
+
+	root := t.TempDir()
+	writeHookScript(t, root, "proj", EventPostSync, "#!/bin/sh\nexit 0\n")
+
+	e := NewExecutor(root)
+	e.Prompter = func(string) bool { return false }
+
+	m := manifestWithHook(EventPostSync)
+
+	if err := e.Run(context.Background(), EventPostSync, m, Context{}); err == nil {
+		t.Fatal("Run with a declined trust prompt = nil error, want failure")
+	}
+}
+
+func TestRunSkipsHooksNotInEnabledList(t *testing.T) {
+	root := t.TempDir()
+	writeHookScript(t, root, "proj", EventPostSync, "#!/bin/sh\nexit 1\n")
+
+	e := NewExecutor(root)
+	e.Prompter = func(string) bool { return true }
+
+	m := manifestWithHook(EventPreUpload)
+
+	if err := e.Run(context.Background(), EventPostSync, m, Context{}); err != nil {
+		t.Fatalf("Run for an event absent from enabled-list = %v, want nil (skipped)", err)
+	}
+}
+
+func TestRunRespectsNoVerify(t *testing.T) {
+	root := t.TempDir()
+	writeHookScript(t, root, "proj", EventPostSync, "#!/bin/sh\nexit 1\n")
+
+	e := NewExecutor(root)
+	e.NoVerify = true
+	e.Prompter = func(string) bool {
+		t.Fatal("Prompter should not be called when NoVerify is set")
+		return false
+	}
+
+	m := manifestWithHook(EventPostSync)
+
+	if err := e.Run(context.Background(), EventPostSync, m, Context{}); err != nil {
+		t.Fatalf("Run with NoVerify = %v, want nil", err)
+	}
+}
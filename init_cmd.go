@@ -0,0 +1,62 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	initURL          string
+	initBranch       string
+	initManifestName string
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "fetch the manifest repository into .repo/manifests",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runInit(cmd.Context())
+	},
+}
+
+// nolint:gochecknoinits
+func initInitCmd() {
+	initCmd.Flags().StringVarP(&initURL, "url", "u", "", "manifest repository URL")
+	initCmd.Flags().StringVarP(&initBranch, "branch", "b", "", "manifest branch to check out")
+	initCmd.Flags().StringVarP(&initManifestName, "manifest-name", "m", "default.xml", "manifest file name within the manifest repository")
+	_ = initCmd.MarkFlagRequired("url")
+
+	rootCmd.AddCommand(initCmd)
+}
+
+// runInit clones initURL into .repo/manifests, mirroring the real repo
+// tool's "repo init" without the interactive prompts it normally offers.
+func runInit(ctx context.Context) error {
+	dest := filepath.Join(".repo", "manifests")
+
+	if _, err := os.Stat(dest); err == nil {
+		return fmt.Errorf("%s already exists; remove it to re-init", dest)
+	}
+
+	opts := &git.CloneOptions{URL: initURL}
+	if initBranch != "" {
+		opts.ReferenceName = plumbing.NewBranchReferenceName(initBranch)
+	}
+
+	if _, err := git.PlainCloneContext(ctx, dest, false, opts); err != nil {
+		return errors.Wrap(err, "failed to clone manifest repository\n")
+	}
+
+	fmt.Fprintf(os.Stderr, "manifest ready at %s\n", filepath.Join(dest, initManifestName))
+
+	return nil
+}
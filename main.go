@@ -6,12 +6,12 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"path"
+	"time"
 
-	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
-	git "github.com/repo-scm/git/mount"
+	"github.com/repo-scm/repo/manifest"
+	"github.com/repo-scm/repo/runtime"
 )
 
 var (
@@ -20,95 +20,91 @@ var (
 )
 
 var (
-	mountPath    string
-	unmountPath  string
-	manifestFile string
-	sshkeyFile   string
+	manifestFile      string
+	localManifestsDir string
+	sshkeyFile        string
+	groupsSpec        string
+	forceCopy         bool
+	noVerify          bool
+	verifyHooks       bool
+	timeout           time.Duration
+	cacheAddr         string
 )
 
 var rootCmd = &cobra.Command{
 	Use:     "repo",
 	Short:   "repo with copy-on-write",
 	Version: BuildTime + "-" + CommitID,
-	Run: func(cmd *cobra.Command, args []string) {
-		ctx := context.Background()
-		if err := run(ctx); err != nil {
-			_, _ = fmt.Fprintln(os.Stderr, err.Error())
-			os.Exit(1)
-		}
-	},
 }
 
+// activeLifecycle is stopped by rootCmd's PersistentPostRunE once whichever
+// subcommand ran has returned successfully; cobra skips PersistentPostRunE
+// when the subcommand returns an error, and main then exits.
+var activeLifecycle *runtime.Lifecycle
+
 // nolint:gochecknoinits
 func init() {
 	cobra.OnInitialize()
 
-	rootCmd.PersistentFlags().StringVarP(&mountPath, "mount", "m", "", "mount path")
-	rootCmd.PersistentFlags().StringVarP(&unmountPath, "unmount", "u", "", "unmount path")
-	rootCmd.PersistentFlags().StringVarP(&manifestFile, "manifest", "n", "", "manifest file (user@host:/remote/manifest.xml:/local/manifest.xml)")
+	rootCmd.PersistentFlags().StringVarP(&manifestFile, "manifest", "n", "", "manifest file (user@host:/remote/manifest.xml:/local/manifest.xml, or a local path)")
+	rootCmd.PersistentFlags().StringVar(&localManifestsDir, "local-manifests", "", "directory of local_manifests/*.xml overlays")
 	rootCmd.PersistentFlags().StringVarP(&sshkeyFile, "sshkey", "s", "", "sshkey file (/path/to/id_rsa)")
+	rootCmd.PersistentFlags().StringVarP(&groupsSpec, "groups", "g", "default", "comma-separated project groups to select (repo tool syntax, e.g. default,-notdefault,platform-linux)")
+	rootCmd.PersistentFlags().BoolVar(&forceCopy, "force-copy", false, "overwrite existing files when materializing copyfile/linkfile")
+	rootCmd.PersistentFlags().BoolVar(&noVerify, "no-verify", false, "skip running repo-hooks")
+	rootCmd.PersistentFlags().BoolVar(&verifyHooks, "verify", false, "run repo-hooks even for events not in enabled-list")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "abort the operation after this long (0 disables)")
+	rootCmd.PersistentFlags().StringVar(&cacheAddr, "cache", "", "blob-cache backend for warm git packs (s3://bucket/prefix, gs://bucket/prefix, file:///var/cache/repo)")
+	rootCmd.MarkFlagsMutuallyExclusive("no-verify", "verify")
+
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		lifecycle := runtime.NewLifecycle(context.Background(), timeout)
+		activeLifecycle = lifecycle
+
+		ctx := lifecycle.Context()
+
+		// mount and unmount take the remote:local form of --manifest and
+		// read the local manifest themselves once sshfs has made it available.
+		if manifestFile != "" && cmd != mountCmd && cmd != unmountCmd {
+			m, prov, err := loadManifest()
+			if err != nil {
+				return fmt.Errorf("failed to load manifest %q: %w", manifestFile, err)
+			}
+			ctx = withManifestContext(ctx, &manifestContext{Manifest: m, Provenance: prov})
+		}
 
-	rootCmd.MarkFlagsOneRequired("mount", "unmount")
-	rootCmd.MarkFlagsMutuallyExclusive("mount", "unmount")
-	_ = rootCmd.MarkFlagRequired("manifest")
-
-	rootCmd.Root().CompletionOptions.DisableDefaultCmd = true
-}
+		cmd.SetContext(ctx)
 
-func main() {
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+		return nil
 	}
-}
-
-func run(ctx context.Context) error {
-	if unmountPath != "" {
-		if err := unmount(ctx, unmountPath); err != nil {
-			return errors.Wrap(err, "failed to unmount repo\n")
+	rootCmd.PersistentPostRunE = func(cmd *cobra.Command, args []string) error {
+		if activeLifecycle != nil {
+			activeLifecycle.Stop()
 		}
+
 		return nil
 	}
 
-	if err := mount(ctx, mountPath); err != nil {
-		return errors.Wrap(err, "failed to mount repo\n")
-	}
+	rootCmd.Root().CompletionOptions.DisableDefaultCmd = true
 
-	return nil
+	initSyncCmd()
+	initMountCmd()
+	initInitCmd()
+	initStatusCmd()
+	initForallCmd()
+	initManifestCmd()
 }
 
-func mount(ctx context.Context, root string) error {
-	remoteManifest, localManifest := git.ParsePath(ctx, manifestFile)
-
-	local := path.Dir(path.Clean(localManifest))
-
-	if remoteManifest != "" {
-		remote := path.Dir(path.Clean(remoteManifest))
-		if err := git.MountSshfs(ctx, sshkeyFile, remote, local); err != nil {
-			return errors.Wrap(err, "failed to mount sshfs\n")
-		}
-	}
-
-	if err := git.MountOverlay(ctx, local, root); err != nil {
-		return errors.Wrap(err, "failed to mount overlay\n")
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
 	}
-
-	return nil
 }
 
-func unmount(ctx context.Context, root string) error {
-	remoteManifest, localManifest := git.ParsePath(ctx, manifestFile)
-
-	local := path.Dir(path.Clean(localManifest))
-
-	if err := git.UnmountOverlay(ctx, local, root); err != nil {
-		return errors.Wrap(err, "failed to unmount overlay\n")
-	}
-
-	if remoteManifest != "" {
-		if err := git.UnmountSshfs(ctx, local); err != nil {
-			return errors.Wrap(err, "failed to unmount sshfs\n")
-		}
-	}
-
-	return nil
+// loadManifest resolves manifestFile into a merged manifest.Manifest,
+// following any <include> elements and local_manifests/*.xml overlays under
+// --local-manifests.
+func loadManifest() (*manifest.Manifest, manifest.Provenance, error) {
+	loader := manifest.NewLoader(localManifestsDir)
+	return loader.Load(manifestFile)
 }
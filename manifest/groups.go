@@ -0,0 +1,105 @@
+package manifest
+
+import "strings"
+
+// defaultGroups are the groups every project implicitly belongs to when it
+// has no explicit groups attribute.
+var defaultGroups = []string{"default", "all"}
+
+// GroupFilter selects projects by the repo tool's group syntax: a
+// comma-separated spec where a leading "-" excludes a group, e.g.
+// "default,-notdefault,platform-linux". Unless the spec overrides it, the
+// implicit "default" group is included.
+type GroupFilter struct {
+	include map[string]bool
+	exclude map[string]bool
+}
+
+// NewGroupFilter parses spec into a GroupFilter.
+func NewGroupFilter(spec string) *GroupFilter {
+	f := &GroupFilter{
+		include: map[string]bool{},
+		exclude: map[string]bool{},
+	}
+
+	for _, group := range strings.Split(spec, ",") {
+		group = strings.TrimSpace(group)
+		if group == "" {
+			continue
+		}
+
+		if strings.HasPrefix(group, "-") {
+			f.exclude[strings.TrimPrefix(group, "-")] = true
+			continue
+		}
+
+		f.include[group] = true
+	}
+
+	// "default" is implicitly selected unless the spec explicitly excludes it.
+	if !f.exclude["default"] {
+		f.include["default"] = true
+	}
+
+	return f
+}
+
+// Match reports whether a project belonging to groups (plus the automatic
+// name:<name> and path:<path> pseudo-groups) is selected by the filter.
+func (f *GroupFilter) Match(groups string, name, path string) bool {
+	projectGroups := projectGroups(groups, name, path)
+
+	for _, g := range projectGroups {
+		if f.exclude[g] {
+			return false
+		}
+	}
+
+	for _, g := range projectGroups {
+		if f.include[g] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// projectGroups returns the effective group membership of a project: its
+// explicit groups (or the implicit default group set if none were given),
+// plus the automatic name:<name> and path:<path> pseudo-groups.
+func projectGroups(groups, name, path string) []string {
+	var list []string
+
+	if groups == "" {
+		list = append(list, defaultGroups...)
+	} else {
+		for _, g := range strings.Split(groups, ",") {
+			g = strings.TrimSpace(g)
+			if g != "" {
+				list = append(list, g)
+			}
+		}
+	}
+
+	list = append(list, "name:"+name)
+	if path != "" {
+		list = append(list, "path:"+path)
+	}
+
+	return list
+}
+
+// FilteredProjects returns the projects selected by spec, using the repo
+// tool's group syntax (see GroupFilter).
+func (m *Manifest) FilteredProjects(spec string) []Project {
+	filter := NewGroupFilter(spec)
+
+	var out []Project
+	for _, p := range m.Project {
+		if filter.Match(p.Groups, p.Name, p.Path) {
+			out = append(out, p)
+		}
+	}
+
+	return out
+}
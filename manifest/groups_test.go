@@ -0,0 +1,106 @@
+package manifest
+
+import "testing"
+
+func TestGroupFilterMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   string
+		groups string
+		want   bool
+	}{
+		{
+			name:   "no groups attribute falls back to default,all",
+			spec:   "default",
+			groups: "",
+			want:   true,
+		},
+		{
+			name:   "implicit default is selected with an empty spec",
+			spec:   "",
+			groups: "",
+			want:   true,
+		},
+		{
+			name:   "explicit include matches",
+			spec:   "platform-linux",
+			groups: "platform-linux",
+			want:   true,
+		},
+		{
+			name:   "explicit include does not match an unrelated group",
+			spec:   "platform-linux",
+			groups: "platform-darwin",
+			want:   false,
+		},
+		{
+			name:   "explicit exclude wins over implicit default",
+			spec:   "-notdefault",
+			groups: "notdefault",
+			want:   false,
+		},
+		{
+			name:   "excluding default removes the implicit membership",
+			spec:   "-default",
+			groups: "",
+			want:   false,
+		},
+		{
+			name:   "exclude takes priority over an explicit include of the same project",
+			spec:   "platform-linux,-notdefault",
+			groups: "platform-linux,notdefault",
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewGroupFilter(tt.spec)
+			if got := f.Match(tt.groups, "proj", "path/to/proj"); got != tt.want {
+				t.Errorf("Match(%q) with spec %q = %v, want %v", tt.groups, tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupFilterNamePathPseudoGroups(t *testing.T) {
+	f := NewGroupFilter("name:foo")
+	if !f.Match("other", "foo", "bar") {
+		t.Error("expected name:foo to match project named foo")
+	}
+	if f.Match("other", "baz", "bar") {
+		t.Error("did not expect name:foo to match project named baz")
+	}
+
+	f = NewGroupFilter("path:bar/baz")
+	if !f.Match("other", "foo", "bar/baz") {
+		t.Error("expected path:bar/baz to match project at bar/baz")
+	}
+}
+
+func TestManifestFilteredProjects(t *testing.T) {
+	m := &Manifest{
+		Project: []Project{
+			{Name: "a", Path: "a"},
+			{Name: "b", Path: "b", Groups: "notdefault"},
+			{Name: "c", Path: "c", Groups: "platform-linux"},
+		},
+	}
+
+	got := m.FilteredProjects("default,-notdefault,platform-linux")
+
+	names := make([]string, 0, len(got))
+	for _, p := range got {
+		names = append(names, p.Name)
+	}
+
+	want := map[string]bool{"a": true, "c": true}
+	if len(names) != len(want) {
+		t.Fatalf("FilteredProjects returned %v, want projects %v", names, want)
+	}
+	for _, n := range names {
+		if !want[n] {
+			t.Errorf("unexpected project %q in filtered result", n)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package manifest
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Provenance maps a merged project's key (see projectKey) to the path of the
+// file that most recently defined or overrode it, for debugging merges.
+type Provenance map[string]string
+
+// Loader loads a manifest file together with any <include>d fragments and
+// local_manifests/*.xml overlays, merging them into a single Manifest.
+type Loader struct {
+	// LocalManifestsDir is globbed for *.xml files applied, in sorted order,
+	// on top of the primary manifest after includes are resolved. Optional.
+	LocalManifestsDir string
+}
+
+// NewLoader builds a Loader that additionally merges *.xml files found in
+// localManifestsDir (normally ".repo/local_manifests"). Pass "" to disable.
+func NewLoader(localManifestsDir string) *Loader {
+	return &Loader{LocalManifestsDir: localManifestsDir}
+}
+
+// Load parses filePath, recursively resolving <include> elements, then
+// merges any local_manifests overlays on top. It returns the merged
+// Manifest plus a Provenance map so callers can debug which file supplied
+// each project.
+func (l *Loader) Load(filePath string) (*Manifest, Provenance, error) {
+	prov := Provenance{}
+
+	merged, err := l.loadFile(filePath, prov, map[string]bool{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if l.LocalManifestsDir == "" {
+		return merged, prov, nil
+	}
+
+	overlays, err := filepath.Glob(filepath.Join(l.LocalManifestsDir, "*.xml"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to glob local manifests: %w", err)
+	}
+	sort.Strings(overlays)
+
+	for _, overlay := range overlays {
+		m, err := l.loadFile(overlay, prov, map[string]bool{})
+		if err != nil {
+			return nil, nil, err
+		}
+		merged = mergeManifest(merged, m, prov, overlay)
+	}
+
+	return merged, prov, nil
+}
+
+// loadFile parses filePath and folds in any <include>d fragments, recording
+// provenance and detecting include cycles via seen (keyed by absolute path).
+func (l *Loader) loadFile(filePath string, prov Provenance, seen map[string]bool) (*Manifest, error) {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve manifest path %q: %w", filePath, err)
+	}
+	if seen[abs] {
+		return nil, fmt.Errorf("include cycle detected at %s", filePath)
+	}
+	seen[abs] = true
+	defer delete(seen, abs)
+
+	m, err := ParseManifest(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range m.Project {
+		prov[projectKey(p)] = filePath
+	}
+
+	dir := filepath.Dir(abs)
+	for _, inc := range m.Include {
+		incPath := inc.Name
+		if !filepath.IsAbs(incPath) {
+			incPath = filepath.Join(dir, incPath)
+		}
+
+		included, err := l.loadFile(incPath, prov, seen)
+		if err != nil {
+			return nil, fmt.Errorf("failed to include %q from %q: %w", inc.Name, filePath, err)
+		}
+
+		m = mergeManifest(included, m, prov, filePath)
+	}
+
+	return m, nil
+}
+
+// projectKey identifies a project for merge purposes by Name+Path, matching
+// the repo tool's own notion of project identity.
+func projectKey(p Project) string {
+	return p.Name + "\x00" + p.Path
+}
+
+// mergeManifest merges overlay on top of base: projects are keyed by
+// Name+Path with later attributes overriding earlier ones field-by-field,
+// remove-project/extend-project are honored, and Remote/Default blocks
+// replace rather than accumulate.
+func mergeManifest(base, overlay *Manifest, prov Provenance, overlaySource string) *Manifest {
+	merged := &Manifest{
+		XMLName: base.XMLName,
+		Notice:  base.Notice,
+		Remote:  base.Remote,
+		Default: base.Default,
+	}
+
+	if overlay.Notice != "" {
+		merged.Notice = overlay.Notice
+	}
+	if len(overlay.Remote) > 0 {
+		merged.Remote = overlay.Remote
+	}
+	if overlay.Default != (Default{}) {
+		merged.Default = overlay.Default
+	}
+
+	projects := make(map[string]Project, len(base.Project)+len(overlay.Project))
+	order := make([]string, 0, len(base.Project)+len(overlay.Project))
+
+	for _, p := range base.Project {
+		key := projectKey(p)
+		projects[key] = p
+		order = append(order, key)
+	}
+
+	for _, p := range overlay.Project {
+		key := projectKey(p)
+		if existing, ok := projects[key]; ok {
+			projects[key] = mergeProject(existing, p)
+		} else {
+			projects[key] = p
+			order = append(order, key)
+		}
+		prov[key] = overlaySource
+	}
+
+	for _, rp := range overlay.RemoveProject {
+		for key := range projects {
+			if strings.HasPrefix(key, rp.Name+"\x00") {
+				delete(projects, key)
+			}
+		}
+	}
+
+	for _, ep := range overlay.ExtendProject {
+		for key, p := range projects {
+			if p.Name != ep.Name {
+				continue
+			}
+			if ep.Path != "" {
+				p.Path = ep.Path
+			}
+			if ep.Revision != "" {
+				p.Revision = ep.Revision
+			}
+			if ep.Groups != "" {
+				p.Groups = ep.Groups
+			}
+			projects[key] = p
+			prov[key] = overlaySource
+		}
+	}
+
+	seen := make(map[string]bool, len(order))
+	merged.Project = make([]Project, 0, len(order))
+	for _, key := range order {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if p, ok := projects[key]; ok {
+			merged.Project = append(merged.Project, p)
+		}
+	}
+
+	merged.RepoHook = base.RepoHook
+	if len(overlay.RepoHook) > 0 {
+		merged.RepoHook = overlay.RepoHook
+	}
+
+	return merged
+}
+
+// mergeProject overrides base with every non-zero field set on overlay.
+func mergeProject(base, overlay Project) Project {
+	merged := base
+
+	if overlay.Remote != "" {
+		merged.Remote = overlay.Remote
+	}
+	if overlay.Revision != "" {
+		merged.Revision = overlay.Revision
+	}
+	if overlay.DestBranch != "" {
+		merged.DestBranch = overlay.DestBranch
+	}
+	if overlay.Groups != "" {
+		merged.Groups = overlay.Groups
+	}
+	if overlay.SyncC != "" {
+		merged.SyncC = overlay.SyncC
+	}
+	if overlay.SyncS != "" {
+		merged.SyncS = overlay.SyncS
+	}
+	if overlay.SyncTags != "" {
+		merged.SyncTags = overlay.SyncTags
+	}
+	if overlay.Upstream != "" {
+		merged.Upstream = overlay.Upstream
+	}
+	if overlay.CloneDepth != "" {
+		merged.CloneDepth = overlay.CloneDepth
+	}
+	if overlay.ForcePath != "" {
+		merged.ForcePath = overlay.ForcePath
+	}
+	if len(overlay.CopyFile) > 0 {
+		merged.CopyFile = overlay.CopyFile
+	}
+	if len(overlay.LinkFile) > 0 {
+		merged.LinkFile = overlay.LinkFile
+	}
+	if len(overlay.Annotation) > 0 {
+		merged.Annotation = overlay.Annotation
+	}
+
+	return merged
+}
@@ -0,0 +1,175 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeManifestFile(t *testing.T, dir, name, xml string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(xml), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	return path
+}
+
+func projectNames(projects []Project) []string {
+	names := make([]string, 0, len(projects))
+	for _, p := range projects {
+		names = append(names, p.Name)
+	}
+
+	return names
+}
+
+func TestLoaderResolvesIncludeAndRecordsProvenance(t *testing.T) {
+	dir := t.TempDir()
+
+	writeManifestFile(t, dir, "included.xml", `<manifest>
+  <project name="b" path="b"/>
+</manifest>`)
+
+	root := writeManifestFile(t, dir, "root.xml", `<manifest>
+  <include name="included.xml"/>
+  <project name="a" path="a"/>
+</manifest>`)
+
+	loader := NewLoader("")
+	m, prov, err := loader.Load(root)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	names := projectNames(m.Project)
+	if len(names) != 2 || names[0] != "b" || names[1] != "a" {
+		t.Fatalf("Project = %v, want [b a] (included project first, then root's own)", names)
+	}
+
+	if prov[projectKey(Project{Name: "a", Path: "a"})] != root {
+		t.Errorf("provenance for %q = %q, want %q", "a", prov[projectKey(Project{Name: "a", Path: "a"})], root)
+	}
+	if prov[projectKey(Project{Name: "b", Path: "b"})] != filepath.Join(dir, "included.xml") {
+		t.Errorf("provenance for %q = %q, want included.xml", "b", prov[projectKey(Project{Name: "b", Path: "b"})])
+	}
+}
+
+func TestLoaderDetectsIncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+
+	writeManifestFile(t, dir, "a.xml", `<manifest>
+  <include name="b.xml"/>
+</manifest>`)
+	writeManifestFile(t, dir, "b.xml", `<manifest>
+  <include name="a.xml"/>
+</manifest>`)
+
+	loader := NewLoader("")
+	_, _, err := loader.Load(filepath.Join(dir, "a.xml"))
+	if err == nil {
+		t.Fatal("Load with an include cycle = nil error, want a cycle error")
+	}
+}
+
+func TestLoaderLocalManifestsAppliedInSortedOrder(t *testing.T) {
+	dir := t.TempDir()
+
+	root := writeManifestFile(t, dir, "root.xml", `<manifest>
+  <project name="a" path="a" revision="main"/>
+</manifest>`)
+
+	localDir := filepath.Join(dir, "local_manifests")
+	writeManifestFile(t, localDir, "02-second.xml", `<manifest>
+  <project name="a" path="a" revision="from-02"/>
+</manifest>`)
+	writeManifestFile(t, localDir, "01-first.xml", `<manifest>
+  <project name="a" path="a" revision="from-01"/>
+</manifest>`)
+
+	loader := NewLoader(localDir)
+	m, _, err := loader.Load(root)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if len(m.Project) != 1 {
+		t.Fatalf("Project = %v, want exactly 1 merged project", m.Project)
+	}
+	// 01-first.xml applies after root (revision "from-01"), then
+	// 02-second.xml applies last (revision "from-02") since local_manifests
+	// overlays are globbed and applied in sorted filename order.
+	if got := m.Project[0].Revision; got != "from-02" {
+		t.Errorf("Project[0].Revision = %q, want %q (last overlay in sorted order wins)", got, "from-02")
+	}
+}
+
+func TestMergeManifestRemoveProjectAndExtendProjectInSameOverlay(t *testing.T) {
+	base := &Manifest{
+		Project: []Project{
+			{Name: "a", Path: "a", Revision: "main"},
+			{Name: "b", Path: "b", Revision: "main"},
+		},
+	}
+	overlay := &Manifest{
+		RemoveProject: []RemoveProject{{Name: "b"}},
+		ExtendProject: []ExtendProject{{Name: "a", Revision: "pinned"}},
+	}
+
+	merged := mergeManifest(base, overlay, Provenance{}, "overlay.xml")
+
+	names := projectNames(merged.Project)
+	if len(names) != 1 || names[0] != "a" {
+		t.Fatalf("Project = %v, want [a] (b removed)", names)
+	}
+	if merged.Project[0].Revision != "pinned" {
+		t.Errorf("Project[0].Revision = %q, want %q", merged.Project[0].Revision, "pinned")
+	}
+}
+
+func TestMergeManifestRemoteAndDefaultReplaceRatherThanAccumulate(t *testing.T) {
+	base := &Manifest{
+		Remote:  []Remote{{Name: "origin", Fetch: "https://base.example.com"}},
+		Default: Default{Remote: "origin", Revision: "main", SyncJ: "4"},
+	}
+	overlay := &Manifest{
+		Remote:  []Remote{{Name: "other", Fetch: "https://other.example.com"}},
+		Default: Default{Remote: "other", Revision: "develop"},
+	}
+
+	merged := mergeManifest(base, overlay, Provenance{}, "overlay.xml")
+
+	if len(merged.Remote) != 1 || merged.Remote[0].Name != "other" {
+		t.Fatalf("Remote = %v, want exactly overlay's [other] (replaced, not accumulated)", merged.Remote)
+	}
+
+	// Default replaces wholesale: overlay's Default{Remote:"other",
+	// Revision:"develop"} fully supersedes base's Default, so SyncJ is lost
+	// rather than retained from base.
+	want := Default{Remote: "other", Revision: "develop"}
+	if merged.Default != want {
+		t.Errorf("Default = %+v, want %+v", merged.Default, want)
+	}
+}
+
+func TestMergeManifestEmptyOverlayRemoteAndDefaultKeepBase(t *testing.T) {
+	base := &Manifest{
+		Remote:  []Remote{{Name: "origin", Fetch: "https://base.example.com"}},
+		Default: Default{Remote: "origin", Revision: "main"},
+	}
+	overlay := &Manifest{}
+
+	merged := mergeManifest(base, overlay, Provenance{}, "overlay.xml")
+
+	if len(merged.Remote) != 1 || merged.Remote[0].Name != "origin" {
+		t.Errorf("Remote = %v, want base's [origin] preserved when overlay has none", merged.Remote)
+	}
+	if merged.Default != base.Default {
+		t.Errorf("Default = %+v, want base's %+v preserved when overlay has none", merged.Default, base.Default)
+	}
+}
@@ -7,12 +7,36 @@ import (
 )
 
 type Manifest struct {
-	XMLName  xml.Name   `xml:"manifest"`
-	Notice   string     `xml:"notice,omitempty"`
-	Remote   []Remote   `xml:"remote"`
-	Default  Default    `xml:"default"`
-	Project  []Project  `xml:"project"`
-	RepoHook []RepoHook `xml:"repo-hooks,omitempty"`
+	XMLName       xml.Name        `xml:"manifest"`
+	Notice        string          `xml:"notice,omitempty"`
+	Include       []Include       `xml:"include,omitempty"`
+	Remote        []Remote        `xml:"remote"`
+	Default       Default         `xml:"default"`
+	Project       []Project       `xml:"project"`
+	RemoveProject []RemoveProject `xml:"remove-project,omitempty"`
+	ExtendProject []ExtendProject `xml:"extend-project,omitempty"`
+	RepoHook      []RepoHook      `xml:"repo-hooks,omitempty"`
+}
+
+// Include models an <include name="..."/> element, composing a base
+// manifest from smaller fragments resolved relative to the including file.
+type Include struct {
+	Name string `xml:"name,attr"`
+}
+
+// RemoveProject models a <remove-project name="..."/> element, dropping a
+// project defined by an earlier-merged manifest.
+type RemoveProject struct {
+	Name string `xml:"name,attr"`
+}
+
+// ExtendProject models an <extend-project name="..." .../> element, patching
+// fields of a project defined by an earlier-merged manifest.
+type ExtendProject struct {
+	Name     string `xml:"name,attr"`
+	Path     string `xml:"path,attr,omitempty"`
+	Revision string `xml:"revision,attr,omitempty"`
+	Groups   string `xml:"groups,attr,omitempty"`
 }
 
 type Remote struct {
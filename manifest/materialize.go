@@ -0,0 +1,241 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stateFile is the name of the file, relative to the mount root's .repo
+// directory, that tracks files/symlinks created by a Materializer so that
+// Cleanup can remove exactly what it created.
+const stateFile = "manifests.state.json"
+
+// materializerState is the persisted record of paths a Materializer created,
+// so a later Cleanup only removes files it is responsible for.
+type materializerState struct {
+	Created []string `json:"created"`
+}
+
+// Materializer executes the <copyfile> and <linkfile> elements of every
+// project in a Manifest: after a project's checkout is in place under root
+// (the mount root, or the sync work dir), CopyFile.Src is copied and
+// LinkFile.Src is symlinked to their Dest, both resolved relative to root.
+type Materializer struct {
+	// Root is the mount/checkout root that Dest paths are resolved against.
+	Root string
+	// Force, when true, allows overwriting an existing file that this
+	// Materializer did not itself create.
+	Force bool
+}
+
+// NewMaterializer builds a Materializer rooted at root.
+func NewMaterializer(root string, force bool) *Materializer {
+	return &Materializer{Root: root, Force: force}
+}
+
+// Materialize executes every CopyFile and LinkFile of projects, recording
+// what it created into root/.repo/manifests.state.json so Cleanup can later
+// remove exactly those paths.
+func (m *Materializer) Materialize(projects []Project) error {
+	state, err := m.loadState()
+	if err != nil {
+		return err
+	}
+	created := make(map[string]bool, len(state.Created))
+	for _, p := range state.Created {
+		created[p] = true
+	}
+
+	otherPaths := make([]string, 0, len(projects))
+	for _, p := range projects {
+		if p.Path != "" {
+			otherPaths = append(otherPaths, p.Path)
+		}
+	}
+
+	for _, project := range projects {
+		projectDir := filepath.Join(m.Root, project.Path)
+
+		for _, cf := range project.CopyFile {
+			dest, err := m.resolveDest(project.Path, otherPaths, cf.Dest)
+			if err != nil {
+				return fmt.Errorf("copyfile %s -> %s: %w", cf.Src, cf.Dest, err)
+			}
+			if err := m.checkOverwrite(dest, created); err != nil {
+				return fmt.Errorf("copyfile %s -> %s: %w", cf.Src, cf.Dest, err)
+			}
+			if err := copyFile(filepath.Join(projectDir, cf.Src), filepath.Join(m.Root, dest)); err != nil {
+				return fmt.Errorf("copyfile %s -> %s: %w", cf.Src, cf.Dest, err)
+			}
+			created[dest] = true
+		}
+
+		for _, lf := range project.LinkFile {
+			dest, err := m.resolveDest(project.Path, otherPaths, lf.Dest)
+			if err != nil {
+				return fmt.Errorf("linkfile %s -> %s: %w", lf.Src, lf.Dest, err)
+			}
+			if err := m.checkOverwrite(dest, created); err != nil {
+				return fmt.Errorf("linkfile %s -> %s: %w", lf.Src, lf.Dest, err)
+			}
+			if err := linkFile(filepath.Join(projectDir, lf.Src), filepath.Join(m.Root, dest)); err != nil {
+				return fmt.Errorf("linkfile %s -> %s: %w", lf.Src, lf.Dest, err)
+			}
+			created[dest] = true
+		}
+	}
+
+	state.Created = make([]string, 0, len(created))
+	for p := range created {
+		state.Created = append(state.Created, p)
+	}
+
+	return m.saveState(state)
+}
+
+// Cleanup removes every file/symlink this Materializer previously created,
+// as recorded in .repo/manifests.state.json, and clears that record.
+func (m *Materializer) Cleanup() error {
+	state, err := m.loadState()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range state.Created {
+		if err := os.Remove(filepath.Join(m.Root, p)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", p, err)
+		}
+	}
+
+	return m.saveState(&materializerState{})
+}
+
+// resolveDest rejects absolute Dest paths, Dest paths that escape the mount
+// root, and Dest paths that land inside a *different* project's checkout
+// tree, then returns the resolved path relative to m.Root.
+func (m *Materializer) resolveDest(ownPath string, otherPaths []string, dest string) (string, error) {
+	if filepath.IsAbs(dest) {
+		return "", fmt.Errorf("dest %q must not be absolute", dest)
+	}
+
+	cleanRoot, err := filepath.Abs(m.Root)
+	if err != nil {
+		return "", err
+	}
+
+	resolved := filepath.Join(cleanRoot, dest)
+
+	rel, err := filepath.Rel(cleanRoot, resolved)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("dest %q escapes mount root", dest)
+	}
+
+	for _, other := range otherPaths {
+		if other == "" || other == ownPath {
+			continue
+		}
+		if rel == other || strings.HasPrefix(rel, other+string(filepath.Separator)) {
+			return "", fmt.Errorf("dest %q traverses into project %q", dest, other)
+		}
+	}
+
+	return rel, nil
+}
+
+func (m *Materializer) checkOverwrite(dest string, created map[string]bool) error {
+	full := filepath.Join(m.Root, dest)
+
+	_, err := os.Lstat(full)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if created[dest] || m.Force {
+		return nil
+	}
+
+	return fmt.Errorf("refusing to overwrite existing file %q (use --force-copy)", dest)
+}
+
+func (m *Materializer) statePath() string {
+	return filepath.Join(m.Root, ".repo", stateFile)
+}
+
+func (m *Materializer) loadState() (*materializerState, error) {
+	data, err := os.ReadFile(m.statePath())
+	if os.IsNotExist(err) {
+		return &materializerState{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read materializer state: %w", err)
+	}
+
+	var state materializerState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse materializer state: %w", err)
+	}
+
+	return &state, nil
+}
+
+func (m *Materializer) saveState(state *materializerState) error {
+	if err := os.MkdirAll(filepath.Dir(m.statePath()), 0o755); err != nil {
+		return fmt.Errorf("failed to create .repo dir: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode materializer state: %w", err)
+	}
+
+	return os.WriteFile(m.statePath(), data, 0o644)
+}
+
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	return os.Chmod(dest, info.Mode())
+}
+
+func linkFile(src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+
+	_ = os.Remove(dest)
+
+	return os.Symlink(src, dest)
+}
@@ -0,0 +1,126 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveDestRejectsAbsoluteAndEscapingPaths(t *testing.T) {
+	m := NewMaterializer(t.TempDir(), false)
+
+	tests := []struct {
+		name string
+		dest string
+	}{
+		{"absolute path", "/etc/passwd"},
+		{"parent traversal", "../outside.txt"},
+		{"nested parent traversal", "sub/../../outside.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.resolveDest("proj", nil, tt.dest); err == nil {
+				t.Errorf("resolveDest(%q) = nil error, want rejection", tt.dest)
+			}
+		})
+	}
+}
+
+func TestResolveDestRejectsOtherProjectPath(t *testing.T) {
+	m := NewMaterializer(t.TempDir(), false)
+
+	if _, err := m.resolveDest("proj-a", []string{"proj-b"}, "proj-b/file.txt"); err == nil {
+		t.Error("resolveDest into another project's checkout = nil error, want rejection")
+	}
+
+	if _, err := m.resolveDest("proj-a", []string{"proj-b"}, "proj-a/file.txt"); err != nil {
+		t.Errorf("resolveDest into own project's checkout = %v, want nil", err)
+	}
+}
+
+func TestMaterializeCopyLinkAndCleanup(t *testing.T) {
+	root := t.TempDir()
+
+	projectDir := filepath.Join(root, "proj")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "SRC"), []byte("content"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	projects := []Project{
+		{
+			Name: "proj",
+			Path: "proj",
+			CopyFile: []CopyFile{
+				{Src: "SRC", Dest: "copied.txt"},
+			},
+			LinkFile: []LinkFile{
+				{Src: "SRC", Dest: "linked.txt"},
+			},
+		},
+	}
+
+	m := NewMaterializer(root, false)
+	if err := m.Materialize(projects); err != nil {
+		t.Fatalf("Materialize: %v", err)
+	}
+
+	copied, err := os.ReadFile(filepath.Join(root, "copied.txt"))
+	if err != nil || string(copied) != "content" {
+		t.Fatalf("copied.txt = %q, %v, want \"content\", nil", copied, err)
+	}
+
+	linked, err := os.Readlink(filepath.Join(root, "linked.txt"))
+	if err != nil {
+		t.Fatalf("Readlink: %v", err)
+	}
+	if linked != filepath.Join(projectDir, "SRC") {
+		t.Errorf("linked.txt -> %q, want %q", linked, filepath.Join(projectDir, "SRC"))
+	}
+
+	if err := m.Cleanup(); err != nil {
+		t.Fatalf("Cleanup: %v", err)
+	}
+
+	for _, name := range []string{"copied.txt", "linked.txt"} {
+		if _, err := os.Lstat(filepath.Join(root, name)); !os.IsNotExist(err) {
+			t.Errorf("%s still exists after Cleanup", name)
+		}
+	}
+}
+
+func TestMaterializeRefusesOverwriteWithoutForce(t *testing.T) {
+	root := t.TempDir()
+
+	projectDir := filepath.Join(root, "proj")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "SRC"), []byte("content"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "existing.txt"), []byte("preexisting"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	projects := []Project{
+		{
+			Name:     "proj",
+			Path:     "proj",
+			CopyFile: []CopyFile{{Src: "SRC", Dest: "existing.txt"}},
+		},
+	}
+
+	m := NewMaterializer(root, false)
+	if err := m.Materialize(projects); err == nil {
+		t.Fatal("Materialize over an existing, non-created file = nil error, want refusal")
+	}
+
+	m.Force = true
+	if err := m.Materialize(projects); err != nil {
+		t.Fatalf("Materialize with Force = %v, want nil", err)
+	}
+}
@@ -0,0 +1,104 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/spf13/cobra"
+
+	"github.com/repo-scm/repo/manifest"
+)
+
+var (
+	manifestResolved bool
+	manifestOutput   string
+)
+
+var manifestCmd = &cobra.Command{
+	Use:   "manifest",
+	Short: "print the fully merged manifest",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runManifest(cmd.Context())
+	},
+}
+
+// nolint:gochecknoinits
+func initManifestCmd() {
+	manifestCmd.Flags().BoolVar(&manifestResolved, "resolved", false, "pin every project to its currently checked-out revision")
+	manifestCmd.Flags().StringVarP(&manifestOutput, "output", "o", "", "write to this file instead of stdout")
+
+	rootCmd.AddCommand(manifestCmd)
+}
+
+func runManifest(ctx context.Context) error {
+	mc, ok := manifestFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("no manifest loaded; pass --manifest")
+	}
+
+	m := mc.Manifest
+	if manifestResolved {
+		m = resolvedManifest(m)
+	}
+
+	data, err := xml.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	data = append(data, '\n')
+
+	if manifestOutput == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	return os.WriteFile(manifestOutput, data, 0o644)
+}
+
+// resolvedManifest returns a copy of m with every project's Revision pinned
+// to its currently checked-out commit, for a reproducible re-sync later.
+func resolvedManifest(m *manifest.Manifest) *manifest.Manifest {
+	resolved := *m
+	resolved.Project = make([]manifest.Project, len(m.Project))
+	copy(resolved.Project, m.Project)
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		return &resolved
+	}
+
+	for i, p := range resolved.Project {
+		dir := p.Path
+		if dir == "" {
+			dir = p.Name
+		}
+
+		rev, err := headRevision(filepath.Join(workDir, dir))
+		if err != nil {
+			continue
+		}
+		resolved.Project[i].Revision = rev
+	}
+
+	return &resolved
+}
+
+func headRevision(dir string) (string, error) {
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return "", err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+
+	return head.Hash().String(), nil
+}
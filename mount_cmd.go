@@ -0,0 +1,268 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	git "github.com/repo-scm/git/mount"
+
+	"github.com/repo-scm/repo/cache"
+	"github.com/repo-scm/repo/hooks"
+	"github.com/repo-scm/repo/manifest"
+	"github.com/repo-scm/repo/runtime"
+	reposync "github.com/repo-scm/repo/sync"
+)
+
+var mountRevision string
+
+var mountCmd = &cobra.Command{
+	Use:   "mount <path>",
+	Short: "mount the manifest as a copy-on-write overlay",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := mount(cmd.Context(), args[0]); err != nil {
+			return errors.Wrap(err, "failed to mount repo\n")
+		}
+		return nil
+	},
+}
+
+var unmountCmd = &cobra.Command{
+	Use:   "unmount <path>",
+	Short: "unmount a copy-on-write overlay mounted by mount",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := unmount(cmd.Context(), args[0]); err != nil {
+			return errors.Wrap(err, "failed to unmount repo\n")
+		}
+		return nil
+	},
+}
+
+// nolint:gochecknoinits
+func initMountCmd() {
+	mountCmd.Flags().StringVar(&mountRevision, "revision", "", "manifest revision/commit this mount is pinned to; required for --cache to reuse a warm lower dir")
+	rootCmd.AddCommand(mountCmd)
+	rootCmd.AddCommand(unmountCmd)
+}
+
+// mount mounts sshfs and the overlay, in that order, registering a rollback
+// step after each success; if a later step fails, or ctx is cancelled before
+// mount completes, everything that succeeded so far is torn down in reverse
+// order before returning.
+func mount(ctx context.Context, root string) error {
+	remoteManifest, localManifest := git.ParsePath(ctx, manifestFile)
+
+	local := path.Dir(path.Clean(localManifest))
+
+	var rollback runtime.RollbackStack
+
+	cacheKey := lowerDirCacheKey(remoteManifest, mountRevision)
+	cacheBackend, warm := primeLowerDirFromCache(ctx, cacheKey, local, mountRevision)
+
+	sshfsMounted := remoteManifest != "" && !warm
+	if sshfsMounted {
+		remote := path.Dir(path.Clean(remoteManifest))
+		if err := git.MountSshfs(ctx, sshkeyFile, remote, local); err != nil {
+			return errors.Wrap(err, "failed to mount sshfs\n")
+		}
+		rollback.Push(func(rctx context.Context) error {
+			return git.UnmountSshfs(rctx, local)
+		})
+	}
+
+	if err := git.MountOverlay(ctx, local, root); err != nil {
+		_ = rollback.Unwind(context.Background())
+		return errors.Wrap(err, "failed to mount overlay\n")
+	}
+	rollback.Push(func(rctx context.Context) error {
+		return git.UnmountOverlay(rctx, local, root)
+	})
+
+	if cacheBackend != nil && !warm {
+		publishLowerDirToCache(ctx, cacheBackend, cacheKey, local)
+	}
+
+	if err := materializeProjects(ctx, localManifest, root); err != nil {
+		_ = rollback.Unwind(context.Background())
+		return errors.Wrap(err, "failed to materialize copyfile/linkfile\n")
+	}
+
+	if err := ctx.Err(); err != nil {
+		_ = rollback.Unwind(context.Background())
+		return errors.Wrap(err, "mount cancelled\n")
+	}
+
+	if err := saveMountState(root, mountState{SshfsMounted: sshfsMounted}); err != nil {
+		_ = rollback.Unwind(context.Background())
+		return errors.Wrap(err, "failed to persist mount state\n")
+	}
+
+	return nil
+}
+
+// mountState records, alongside the materializer's own
+// manifests.state.json, what mount actually did for this checkout root so
+// unmount can reverse exactly that rather than re-deriving it from
+// manifestFile (which can't tell whether --cache served a warm lower dir
+// and skipped sshfs).
+type mountState struct {
+	SshfsMounted bool `json:"sshfs_mounted"`
+}
+
+func mountStatePath(root string) string {
+	return filepath.Join(root, ".repo", "mount.state.json")
+}
+
+func saveMountState(root string, state mountState) error {
+	if err := os.MkdirAll(filepath.Join(root, ".repo"), 0o755); err != nil {
+		return fmt.Errorf("failed to create .repo dir: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode mount state: %w", err)
+	}
+
+	return os.WriteFile(mountStatePath(root), data, 0o644)
+}
+
+func loadMountState(root string) mountState {
+	data, err := os.ReadFile(mountStatePath(root))
+	if err != nil {
+		return mountState{}
+	}
+
+	var state mountState
+	_ = json.Unmarshal(data, &state)
+
+	return state
+}
+
+func removeMountState(root string) error {
+	if err := os.Remove(mountStatePath(root)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// lowerDirCacheKey derives a cache.Backend key for the overlay's lower dir
+// from the manifest repo's remote path plus the pinned revision, so a
+// cached entry is only reused for the exact revision it was produced from;
+// with an empty revision the key depends on remoteManifest alone, and
+// primeLowerDirFromCache never reads such a key back.
+func lowerDirCacheKey(remoteManifest, revision string) string {
+	sum := sha256.Sum256([]byte(remoteManifest + revision))
+	return hex.EncodeToString(sum[:])
+}
+
+// primeLowerDirFromCache best-effort populates local from --cache before
+// falling back to sshfs. It returns the configured backend (nil if --cache
+// is unset) and whether local was successfully warmed from it.
+//
+// Reuse requires a non-empty revision: a cache entry is only ever safe to
+// serve in place of a live mount when it's keyed to an immutable, pinned
+// commit, so without --revision every mount talks to the remote instead of silently
+// serving whatever the cache last saw.
+func primeLowerDirFromCache(ctx context.Context, key, local, revision string) (cache.Backend, bool) {
+	if cacheAddr == "" {
+		return nil, false
+	}
+
+	backend, err := cache.FromURL(cacheAddr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to configure cache: %s\n", err.Error())
+		return nil, false
+	}
+
+	if revision == "" {
+		fmt.Fprintf(os.Stderr, "--cache set without --revision; mounting fresh instead of trusting a cached lower dir\n")
+		return backend, false
+	}
+
+	rc, err := backend.Get(ctx, key)
+	if err != nil {
+		return backend, false
+	}
+	defer rc.Close()
+
+	if err := reposync.ExtractArchive(local, rc); err != nil {
+		return backend, false
+	}
+
+	return backend, true
+}
+
+// publishLowerDirToCache best-effort uploads local to backend under key
+// after a successful sshfs + overlay mount, for the next runner to reuse.
+func publishLowerDirToCache(ctx context.Context, backend cache.Backend, key, local string) {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(reposync.ArchiveDir(local, pw))
+	}()
+
+	_ = backend.Put(ctx, key, pr)
+}
+
+// materializeProjects best-effort parses the mounted manifest, runs its
+// copyfile/linkfile elements against root, and fires the post-checkout
+// repo-hooks event; the mount path otherwise still operates on the whole
+// overlay rather than per-project.
+func materializeProjects(ctx context.Context, localManifest, root string) error {
+	m, err := manifest.ParseManifest(localManifest)
+	if err != nil {
+		return nil
+	}
+
+	filtered := m.FilteredProjects(groupsSpec)
+	fmt.Fprintf(os.Stderr, "%d/%d projects match groups %q\n", len(filtered), len(m.Project), groupsSpec)
+
+	materializer := manifest.NewMaterializer(root, forceCopy)
+	if err := materializer.Materialize(filtered); err != nil {
+		return err
+	}
+
+	executor := hooks.NewExecutor(root)
+	executor.NoVerify = noVerify
+	executor.Verify = verifyHooks
+
+	return executor.Run(ctx, hooks.EventPostCheckout, m, hooks.Context{ManifestURL: manifestFile})
+}
+
+func unmount(ctx context.Context, root string) error {
+	_, localManifest := git.ParsePath(ctx, manifestFile)
+
+	local := path.Dir(path.Clean(localManifest))
+
+	state := loadMountState(root)
+
+	materializer := manifest.NewMaterializer(root, forceCopy)
+	if err := materializer.Cleanup(); err != nil {
+		return errors.Wrap(err, "failed to clean up copyfile/linkfile\n")
+	}
+
+	if err := git.UnmountOverlay(ctx, local, root); err != nil {
+		return errors.Wrap(err, "failed to unmount overlay\n")
+	}
+
+	if state.SshfsMounted {
+		if err := git.UnmountSshfs(ctx, local); err != nil {
+			return errors.Wrap(err, "failed to unmount sshfs\n")
+		}
+	}
+
+	return removeMountState(root)
+}
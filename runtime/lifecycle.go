@@ -0,0 +1,79 @@
+// Package runtime provides the process-lifecycle plumbing shared by repo's
+// subcommands: a cancellable root context tied to OS signals and an optional
+// deadline, plus a rollback stack for undoing partial work on failure.
+package runtime
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Lifecycle owns the root context.Context for a single repo invocation.
+// The context is cancelled when the process receives SIGINT or SIGTERM, and
+// additionally after timeout elapses if timeout > 0.
+type Lifecycle struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewLifecycle derives a Lifecycle's context from parent, cancelling it on
+// SIGINT/SIGTERM and, if timeout > 0, after that duration elapses.
+func NewLifecycle(parent context.Context, timeout time.Duration) *Lifecycle {
+	ctx, cancel := signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+
+	if timeout > 0 {
+		timeoutCtx, timeoutCancel := context.WithTimeout(ctx, timeout)
+		signalCancel := cancel
+
+		ctx = timeoutCtx
+		cancel = func() {
+			timeoutCancel()
+			signalCancel()
+		}
+	}
+
+	return &Lifecycle{ctx: ctx, cancel: cancel}
+}
+
+// Context returns the lifecycle's root context.
+func (l *Lifecycle) Context() context.Context {
+	return l.ctx
+}
+
+// Stop releases the signal notification (and timeout, if any) backing the
+// lifecycle's context. Call it once the lifecycle is no longer needed.
+func (l *Lifecycle) Stop() {
+	l.cancel()
+}
+
+// RollbackStack runs a sequence of cleanup steps, in reverse order, the
+// first time Unwind is called. It is not safe for concurrent use.
+type RollbackStack struct {
+	steps []func(context.Context) error
+}
+
+// Push registers step to run when Unwind is called, ahead of any steps
+// already registered.
+func (r *RollbackStack) Push(step func(context.Context) error) {
+	r.steps = append(r.steps, step)
+}
+
+// Unwind runs every registered step in reverse registration order using ctx
+// (normally context.Background(), since the lifecycle's own context may
+// already be cancelled or expired), then clears the stack. It returns the
+// first error encountered, if any, after running every step.
+func (r *RollbackStack) Unwind(ctx context.Context) error {
+	var first error
+
+	for i := len(r.steps) - 1; i >= 0; i-- {
+		if err := r.steps[i](ctx); err != nil && first == nil {
+			first = err
+		}
+	}
+	r.steps = nil
+
+	return first
+}
@@ -0,0 +1,75 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "show the working-tree status of every project",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runStatus(cmd.Context())
+	},
+}
+
+// nolint:gochecknoinits
+func initStatusCmd() {
+	rootCmd.AddCommand(statusCmd)
+}
+
+func runStatus(ctx context.Context) error {
+	mc, ok := manifestFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("no manifest loaded; pass --manifest")
+	}
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range mc.Manifest.FilteredProjects(groupsSpec) {
+		dir := p.Path
+		if dir == "" {
+			dir = p.Name
+		}
+		dir = filepath.Join(workDir, dir)
+
+		repo, err := git.PlainOpen(dir)
+		if err != nil {
+			fmt.Printf("%s: not checked out\n", p.Name)
+			continue
+		}
+
+		worktree, err := repo.Worktree()
+		if err != nil {
+			fmt.Printf("%s: error: %s\n", p.Name, err.Error())
+			continue
+		}
+
+		st, err := worktree.Status()
+		if err != nil {
+			fmt.Printf("%s: error: %s\n", p.Name, err.Error())
+			continue
+		}
+
+		if st.IsClean() {
+			continue
+		}
+
+		fmt.Printf("project %s\n", p.Name)
+		for file, s := range st {
+			fmt.Printf("  %c%c %s\n", s.Staging, s.Worktree, file)
+		}
+	}
+
+	return nil
+}
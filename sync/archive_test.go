@@ -0,0 +1,81 @@
+package sync
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchiveDirExtractArchiveRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "objects", "pack"), 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "objects", "pack", "pack-1.pack"), []byte("packdata"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ArchiveDir(src, &buf); err != nil {
+		t.Fatalf("ArchiveDir: %v", err)
+	}
+
+	dest := t.TempDir()
+	if err := ExtractArchive(dest, &buf); err != nil {
+		t.Fatalf("ExtractArchive: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dest, "objects", "pack", "pack-1.pack"))
+	if err != nil {
+		t.Fatalf("extracted file missing: %v", err)
+	}
+	if string(got) != "packdata" {
+		t.Errorf("extracted content = %q, want %q", got, "packdata")
+	}
+}
+
+// buildTarGz builds a gzipped tar archive containing a single entry with
+// the given name and content, bypassing ArchiveDir so a malicious name can
+// be injected directly.
+func buildTarGz(t *testing.T, name string, content []byte) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	if err := tw.WriteHeader(&tar.Header{
+		Name: name,
+		Mode: 0o644,
+		Size: int64(len(content)),
+	}); err != nil {
+		t.Fatalf("WriteHeader: %v", err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("tar Close: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("gzip Close: %v", err)
+	}
+
+	return &buf
+}
+
+func TestExtractArchiveRejectsPathEscape(t *testing.T) {
+	dest := t.TempDir()
+	archive := buildTarGz(t, "../evil.txt", []byte("pwned"))
+
+	if err := ExtractArchive(dest, archive); err == nil {
+		t.Fatal("expected an error extracting a tar entry that escapes dest, got nil")
+	}
+
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dest), "evil.txt")); !os.IsNotExist(err) {
+		t.Errorf("escaping entry was written outside dest")
+	}
+}
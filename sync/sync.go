@@ -0,0 +1,429 @@
+// Package sync clones and updates the projects described by a manifest.Manifest
+// into per-project bare git directories and checks them out at their pinned revision.
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/pkg/errors"
+
+	"github.com/repo-scm/repo/cache"
+	"github.com/repo-scm/repo/manifest"
+)
+
+const (
+	// defaultJobs is the worker-pool size used when Default.SyncJ is unset or invalid.
+	defaultJobs = 4
+
+	// projectsDir is the subdirectory of the repo root holding bare project clones.
+	projectsDir = "projects"
+)
+
+// Reporter receives progress updates while a Syncer works through a manifest.
+// Implementations must be safe for concurrent use.
+type Reporter interface {
+	// Start is called once with the total number of projects about to be synced.
+	Start(total int)
+	// Done is called once per project as it finishes (err is nil on success).
+	Done(project manifest.Project, err error)
+}
+
+type nopReporter struct{}
+
+func (nopReporter) Start(int)                    {}
+func (nopReporter) Done(manifest.Project, error) {}
+
+// Syncer fetches and checks out every project in a Manifest.
+type Syncer struct {
+	// RepoRoot is the ".repo"-style directory under which bare clones are kept.
+	RepoRoot string
+	// SSHKey is an optional private key file used for ssh:// remotes.
+	SSHKey string
+	// Jobs is the worker-pool size; zero means derive it from Default.SyncJ.
+	Jobs int
+	// Reporter receives per-project progress; defaults to a no-op if nil.
+	Reporter Reporter
+	// Cache, if set, is consulted for a warm copy of a project's bare repo
+	// before fetching, and updated with the result after a successful fetch.
+	Cache cache.Backend
+}
+
+// NewSyncer builds a Syncer rooted at repoRoot (normally ".repo").
+func NewSyncer(repoRoot, sshKey string) *Syncer {
+	return &Syncer{
+		RepoRoot: repoRoot,
+		SSHKey:   sshKey,
+		Reporter: nopReporter{},
+	}
+}
+
+// Sync fetches and checks out every project selected from m, writing checkouts
+// relative to workDir (normally the mount root or repository top).
+func (s *Syncer) Sync(ctx context.Context, m *manifest.Manifest, workDir string, projects []manifest.Project) error {
+	reporter := s.Reporter
+	if reporter == nil {
+		reporter = nopReporter{}
+	}
+
+	jobs := s.Jobs
+	if jobs <= 0 {
+		jobs = jobsFromDefault(m.Default.SyncJ)
+	}
+
+	reporter.Start(len(projects))
+
+	remotes := indexRemotes(m.Remote)
+
+	sem := make(chan struct{}, jobs)
+	errs := make([]error, len(projects))
+
+	var wg sync.WaitGroup
+	for i, p := range projects {
+		i, p := i, p
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := s.syncProject(ctx, m, remotes, workDir, p)
+			errs[i] = err
+			reporter.Done(p, err)
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return errors.Wrapf(err, "failed to sync project %q", projects[i].Name)
+		}
+	}
+
+	return nil
+}
+
+func (s *Syncer) syncProject(ctx context.Context, m *manifest.Manifest, remotes map[string]manifest.Remote, workDir string, p manifest.Project) error {
+	remote, err := resolveRemote(m, remotes, p)
+	if err != nil {
+		return errors.Wrap(err, "failed to resolve remote")
+	}
+
+	fetchURL := fetchURL(remote, p)
+
+	bareDir := filepath.Join(s.RepoRoot, projectsDir, p.Name+".git")
+	if err := os.MkdirAll(filepath.Dir(bareDir), 0o755); err != nil {
+		return errors.Wrap(err, "failed to create project dir")
+	}
+
+	revision := p.Revision
+	if revision == "" {
+		revision = m.Default.Revision
+	}
+
+	cacheKey := packCacheKey(fetchURL, revision)
+	s.primeFromCache(ctx, cacheKey, bareDir)
+
+	repo, err := openOrInitBare(bareDir)
+	if err != nil {
+		return errors.Wrap(err, "failed to init bare repo")
+	}
+
+	auth, err := s.auth(fetchURL)
+	if err != nil {
+		return errors.Wrap(err, "failed to configure auth")
+	}
+
+	depth := 0
+	if p.CloneDepth != "" {
+		depth, err = strconv.Atoi(p.CloneDepth)
+		if err != nil {
+			return errors.Wrapf(err, "invalid clone-depth %q", p.CloneDepth)
+		}
+	}
+
+	refSpecs := []config.RefSpec{"+refs/heads/*:refs/remotes/origin/*"}
+	if p.SyncC == "true" && revision != "" {
+		// sync-c: only the branch the manifest currently pins, not every
+		// branch on the remote.
+		refSpecs = []config.RefSpec{
+			config.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", revision, revision)),
+		}
+	}
+
+	fetchOpts := &git.FetchOptions{
+		RemoteName: "origin",
+		RefSpecs:   refSpecs,
+		Depth:      depth,
+		Auth:       auth,
+		Tags:       git.NoTags,
+	}
+	if p.SyncTags == "true" {
+		fetchOpts.Tags = git.AllTags
+	}
+
+	if err := ensureRemote(repo, fetchURL); err != nil {
+		return errors.Wrap(err, "failed to set remote")
+	}
+
+	if err := repo.FetchContext(ctx, fetchOpts); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return errors.Wrap(err, "failed to fetch")
+	}
+
+	s.publishToCache(ctx, cacheKey, bareDir)
+
+	checkoutDir := p.Path
+	if checkoutDir == "" {
+		checkoutDir = p.Name
+	}
+	checkoutDir = filepath.Join(workDir, checkoutDir)
+
+	if err := checkoutWorktree(bareDir, checkoutDir, revision, p.SyncS == "true"); err != nil {
+		return errors.Wrap(err, "failed to checkout")
+	}
+
+	return nil
+}
+
+// resolveRemote implements Project.Remote -> Default.Remote -> matching Manifest.Remote.
+func resolveRemote(m *manifest.Manifest, remotes map[string]manifest.Remote, p manifest.Project) (manifest.Remote, error) {
+	name := p.Remote
+	if name == "" {
+		name = m.Default.Remote
+	}
+
+	remote, ok := remotes[name]
+	if !ok {
+		return manifest.Remote{}, fmt.Errorf("no remote named %q for project %q", name, p.Name)
+	}
+
+	return remote, nil
+}
+
+// fetchURL computes Remote.Fetch + Remote.Alias?/Project.Name. It joins with
+// plain string concatenation rather than path.Join, which collapses the
+// "//" after a URL scheme (turning "https://github.com" into
+// "https:/github.com") and would break every non-local-path remote.
+func fetchURL(remote manifest.Remote, p manifest.Project) string {
+	base := remote.Fetch
+	if remote.Alias != "" {
+		base = joinURLPath(base, remote.Alias)
+	}
+
+	return joinURLPath(base, p.Name)
+}
+
+// joinURLPath concatenates base and elem with exactly one "/" between them,
+// without touching "//" elsewhere in base (e.g. a URL scheme).
+func joinURLPath(base, elem string) string {
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(elem, "/")
+}
+
+func indexRemotes(remotes []manifest.Remote) map[string]manifest.Remote {
+	out := make(map[string]manifest.Remote, len(remotes))
+	for _, r := range remotes {
+		out[r.Name] = r
+	}
+
+	return out
+}
+
+func jobsFromDefault(syncJ string) int {
+	if syncJ == "" {
+		return defaultJobs
+	}
+
+	n, err := strconv.Atoi(syncJ)
+	if err != nil || n <= 0 {
+		return defaultJobs
+	}
+
+	return n
+}
+
+// packCacheKey derives a cache.Backend key from a project's fetch URL and
+// revision string, so a cached pack is only reused for the same URL and
+// revision; when revision names a branch, the key stays the same as the
+// branch moves.
+func packCacheKey(fetchURL, revision string) string {
+	sum := sha256.Sum256([]byte(fetchURL + revision))
+	return hex.EncodeToString(sum[:])
+}
+
+// primeFromCache best-effort populates bareDir from s.Cache; a cache miss or
+// any other error is not fatal, since bareDir is then simply fetched
+// normally.
+func (s *Syncer) primeFromCache(ctx context.Context, key, bareDir string) {
+	if s.Cache == nil {
+		return
+	}
+
+	rc, err := s.Cache.Get(ctx, key)
+	if err != nil {
+		return
+	}
+	defer rc.Close()
+
+	_ = ExtractArchive(bareDir, rc)
+}
+
+// publishToCache best-effort uploads bareDir to s.Cache so a later sync (on
+// this or another runner) starts from a warm bare dir and fetches only what
+// it lacks.
+func (s *Syncer) publishToCache(ctx context.Context, key, bareDir string) {
+	if s.Cache == nil {
+		return
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(ArchiveDir(bareDir, pw))
+	}()
+
+	_ = s.Cache.Put(ctx, key, pr)
+}
+
+func (s *Syncer) auth(fetchURL string) (transport.AuthMethod, error) {
+	if s.SSHKey == "" {
+		return nil, nil
+	}
+
+	ep, err := transport.NewEndpoint(fetchURL)
+	if err != nil || ep.Protocol != "ssh" {
+		return nil, nil
+	}
+
+	publicKeys, err := gitssh.NewPublicKeysFromFile("git", s.SSHKey, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load sshkey")
+	}
+
+	return publicKeys, nil
+}
+
+func openOrInitBare(bareDir string) (*git.Repository, error) {
+	repo, err := git.PlainOpen(bareDir)
+	if err == nil {
+		return repo, nil
+	}
+
+	return git.PlainInit(bareDir, true)
+}
+
+// ensureRemote makes sure repo has an "origin" remote pointing at fetchURL,
+// recreating it if it already exists with a different URL. A no-op when
+// "origin" already points at fetchURL, so a second sync doesn't tear down
+// the remote it needs to fetch with.
+func ensureRemote(repo *git.Repository, fetchURL string) error {
+	existing, err := repo.Remote("origin")
+	if err == nil {
+		urls := existing.Config().URLs
+		if len(urls) == 1 && urls[0] == fetchURL {
+			return nil
+		}
+
+		if err := repo.DeleteRemote("origin"); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, git.ErrRemoteNotFound) {
+		return err
+	}
+
+	_, err = repo.CreateRemote(&config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{fetchURL},
+	})
+
+	return err
+}
+
+// checkoutWorktree materializes dir as a working tree pulled from the bare
+// project clone at bareDir, checked out at revision. It fetches the
+// remote-tracking refs from bareDir directly rather than using
+// git.PlainClone, since bareDir (a fetch mirror with no refs/heads/* of its
+// own) has no resolvable default branch for PlainClone's HEAD detection to
+// land on.
+func checkoutWorktree(bareDir, dir, revision string, syncSubmodules bool) error {
+	checkout, err := git.PlainOpen(dir)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		checkout, err = git.PlainInit(dir, false)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := ensureRemote(checkout, bareDir); err != nil {
+		return err
+	}
+
+	// bareDir is itself a fetch mirror: what it has are remote-tracking
+	// refs under refs/remotes/origin/*, not refs/heads/*, so that's what
+	// gets mirrored into dir's own refs/remotes/origin/*.
+	fetchOpts := &git.FetchOptions{
+		RemoteName: "origin",
+		RefSpecs:   []config.RefSpec{"+refs/remotes/origin/*:refs/remotes/origin/*"},
+		Tags:       git.AllTags,
+	}
+	if err := checkout.Fetch(fetchOpts); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return err
+	}
+
+	worktree, err := checkout.Worktree()
+	if err != nil {
+		return err
+	}
+
+	hash, err := resolveRevision(checkout, revision)
+	if err != nil {
+		return err
+	}
+
+	if err := worktree.Checkout(&git.CheckoutOptions{
+		Hash:  hash,
+		Force: true,
+	}); err != nil {
+		return err
+	}
+
+	if !syncSubmodules {
+		return nil
+	}
+
+	submodules, err := worktree.Submodules()
+	if err != nil {
+		return err
+	}
+
+	return submodules.Update(&git.SubmoduleUpdateOptions{
+		Init:              true,
+		RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
+	})
+}
+
+func resolveRevision(repo *git.Repository, revision string) (plumbing.Hash, error) {
+	ref, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", revision), true)
+	if err == nil {
+		return ref.Hash(), nil
+	}
+
+	ref, err = repo.Reference(plumbing.NewTagReferenceName(revision), true)
+	if err == nil {
+		return ref.Hash(), nil
+	}
+
+	return plumbing.NewHash(revision), nil
+}
@@ -0,0 +1,213 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/repo-scm/repo/manifest"
+)
+
+// newUpstreamRepo creates a non-bare working repo at dir with a single
+// commit on "master", returning the commit hash.
+func newUpstreamRepo(t *testing.T, dir string) string {
+	t.Helper()
+
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("PlainInit: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Worktree: %v", err)
+	}
+
+	if _, err := wt.Add("file.txt"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	hash, err := wt.Commit("initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	return hash.String()
+}
+
+func TestSyncerSyncFetchesAndChecksOut(t *testing.T) {
+	tmp := t.TempDir()
+	upstream := filepath.Join(tmp, "upstream")
+	newUpstreamRepo(t, upstream)
+
+	repoRoot := filepath.Join(tmp, ".repo")
+	workDir := filepath.Join(tmp, "work")
+
+	m := &manifest.Manifest{
+		Remote:  []manifest.Remote{{Name: "origin", Fetch: tmp}},
+		Default: manifest.Default{Remote: "origin", Revision: "master"},
+	}
+	project := manifest.Project{Name: "upstream"}
+
+	syncer := NewSyncer(repoRoot, "")
+
+	if err := syncer.Sync(context.Background(), m, workDir, []manifest.Project{project}); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(workDir, "upstream", "file.txt"))
+	if err != nil {
+		t.Fatalf("checkout did not materialize file.txt: %v", err)
+	}
+	if string(got) != "hello\n" {
+		t.Errorf("file.txt = %q, want %q", got, "hello\n")
+	}
+}
+
+func TestSyncerSyncTwiceReusesRemote(t *testing.T) {
+	tmp := t.TempDir()
+	upstream := filepath.Join(tmp, "upstream")
+	newUpstreamRepo(t, upstream)
+
+	repoRoot := filepath.Join(tmp, ".repo")
+	workDir := filepath.Join(tmp, "work")
+
+	m := &manifest.Manifest{
+		Remote:  []manifest.Remote{{Name: "origin", Fetch: tmp}},
+		Default: manifest.Default{Remote: "origin", Revision: "master"},
+	}
+	project := manifest.Project{Name: "upstream"}
+
+	syncer := NewSyncer(repoRoot, "")
+
+	// A second sync against the same (already-populated) bare dir and
+	// checkout must not fail because ensureRemote tore down "origin"
+	// without recreating it.
+	for i := 0; i < 2; i++ {
+		if err := syncer.Sync(context.Background(), m, workDir, []manifest.Project{project}); err != nil {
+			t.Fatalf("Sync #%d: %v", i+1, err)
+		}
+	}
+}
+
+func TestEnsureRemoteRecreatesOnURLChange(t *testing.T) {
+	tmp := t.TempDir()
+	bareDir := filepath.Join(tmp, "bare.git")
+
+	repo, err := git.PlainInit(bareDir, true)
+	if err != nil {
+		t.Fatalf("PlainInit: %v", err)
+	}
+
+	if err := ensureRemote(repo, "https://example.com/a.git"); err != nil {
+		t.Fatalf("ensureRemote (create): %v", err)
+	}
+
+	if err := ensureRemote(repo, "https://example.com/a.git"); err != nil {
+		t.Fatalf("ensureRemote (no-op on same URL): %v", err)
+	}
+
+	if err := ensureRemote(repo, "https://example.com/b.git"); err != nil {
+		t.Fatalf("ensureRemote (recreate on new URL): %v", err)
+	}
+
+	remote, err := repo.Remote("origin")
+	if err != nil {
+		t.Fatalf("origin remote missing after ensureRemote: %v", err)
+	}
+
+	urls := remote.Config().URLs
+	if len(urls) != 1 || urls[0] != "https://example.com/b.git" {
+		t.Errorf("origin URLs = %v, want [https://example.com/b.git]", urls)
+	}
+}
+
+func TestFetchURLPreservesURLScheme(t *testing.T) {
+	tests := []struct {
+		name   string
+		remote manifest.Remote
+		proj   manifest.Project
+		want   string
+	}{
+		{
+			name:   "https remote, no alias",
+			remote: manifest.Remote{Fetch: "https://github.com"},
+			proj:   manifest.Project{Name: "project1"},
+			want:   "https://github.com/project1",
+		},
+		{
+			name:   "ssh remote with alias",
+			remote: manifest.Remote{Fetch: "ssh://git@host", Alias: "org"},
+			proj:   manifest.Project{Name: "project"},
+			want:   "ssh://git@host/org/project",
+		},
+		{
+			name:   "fetch with trailing slash",
+			remote: manifest.Remote{Fetch: "https://github.com/"},
+			proj:   manifest.Project{Name: "project1"},
+			want:   "https://github.com/project1",
+		},
+		{
+			name:   "plain filesystem path still joins correctly",
+			remote: manifest.Remote{Fetch: "/srv/git"},
+			proj:   manifest.Project{Name: "project1"},
+			want:   "/srv/git/project1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fetchURL(tt.remote, tt.proj); got != tt.want {
+				t.Errorf("fetchURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncerSyncResolvesTagRevision(t *testing.T) {
+	tmp := t.TempDir()
+	upstream := filepath.Join(tmp, "upstream")
+	hash := newUpstreamRepo(t, upstream)
+
+	upstreamRepo, err := git.PlainOpen(upstream)
+	if err != nil {
+		t.Fatalf("PlainOpen: %v", err)
+	}
+	if _, err := upstreamRepo.CreateTag("v1.0.0", plumbing.NewHash(hash), nil); err != nil {
+		t.Fatalf("CreateTag: %v", err)
+	}
+
+	repoRoot := filepath.Join(tmp, ".repo")
+	workDir := filepath.Join(tmp, "work")
+
+	m := &manifest.Manifest{
+		Remote:  []manifest.Remote{{Name: "origin", Fetch: tmp}},
+		Default: manifest.Default{Remote: "origin"},
+	}
+	project := manifest.Project{Name: "upstream", Revision: "v1.0.0", SyncTags: "true"}
+
+	syncer := NewSyncer(repoRoot, "")
+
+	if err := syncer.Sync(context.Background(), m, workDir, []manifest.Project{project}); err != nil {
+		t.Fatalf("Sync with tag revision: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(workDir, "upstream", "file.txt"))
+	if err != nil {
+		t.Fatalf("checkout did not materialize file.txt: %v", err)
+	}
+	if string(got) != "hello\n" {
+		t.Errorf("file.txt = %q, want %q", got, "hello\n")
+	}
+}
@@ -0,0 +1,130 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/repo-scm/repo/cache"
+	"github.com/repo-scm/repo/hooks"
+	"github.com/repo-scm/repo/manifest"
+	reposync "github.com/repo-scm/repo/sync"
+)
+
+var syncJobs int
+
+var syncCmd = &cobra.Command{
+	Use:   "sync [project...]",
+	Short: "fetch and checkout projects in the manifest",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSync(cmd.Context(), args)
+	},
+}
+
+// nolint:gochecknoinits
+func initSyncCmd() {
+	syncCmd.Flags().IntVarP(&syncJobs, "jobs", "j", 0, "number of concurrent project syncs (default: Default.SyncJ or 4)")
+	rootCmd.AddCommand(syncCmd)
+}
+
+// progressReporter prints a one-line status per project as syncs complete.
+type progressReporter struct {
+	mu   sync.Mutex
+	done int
+}
+
+func (r *progressReporter) Start(total int) {
+	fmt.Fprintf(os.Stderr, "syncing %d projects\n", total)
+}
+
+func (r *progressReporter) Done(project manifest.Project, err error) {
+	r.mu.Lock()
+	r.done++
+	n := r.done
+	r.mu.Unlock()
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[%d] %s: error: %s\n", n, project.Name, err.Error())
+		return
+	}
+	fmt.Fprintf(os.Stderr, "[%d] %s: done\n", n, project.Name)
+}
+
+func runSync(ctx context.Context, names []string) error {
+	mc, ok := manifestFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("no manifest loaded; pass --manifest")
+	}
+	m := mc.Manifest
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		return errors.Wrap(err, "failed to resolve working directory\n")
+	}
+
+	syncer := reposync.NewSyncer(".repo", sshkeyFile)
+	syncer.Jobs = syncJobs
+	syncer.Reporter = &progressReporter{}
+
+	if cacheAddr != "" {
+		backend, err := cache.FromURL(cacheAddr)
+		if err != nil {
+			return errors.Wrap(err, "failed to configure cache\n")
+		}
+		syncer.Cache = backend
+	}
+
+	projects := m.FilteredProjects(groupsSpec)
+
+	if len(names) > 0 {
+		projects, err = filterProjectsByNames(projects, names)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := syncer.Sync(ctx, m, workDir, projects); err != nil {
+		return errors.Wrap(err, "failed to sync projects\n")
+	}
+
+	materializer := manifest.NewMaterializer(workDir, forceCopy)
+	if err := materializer.Materialize(projects); err != nil {
+		return errors.Wrap(err, "failed to materialize copyfile/linkfile\n")
+	}
+
+	executor := hooks.NewExecutor(workDir)
+	executor.NoVerify = noVerify
+	executor.Verify = verifyHooks
+
+	if err := executor.Run(ctx, hooks.EventPostSync, m, hooks.Context{ManifestURL: manifestFile}); err != nil {
+		return errors.Wrap(err, "failed to run post-sync hooks\n")
+	}
+
+	return nil
+}
+
+// filterProjectsByNames narrows projects (already filtered by --groups) down
+// to the ones named, preserving the order names were given in.
+func filterProjectsByNames(projects []manifest.Project, names []string) ([]manifest.Project, error) {
+	byName := make(map[string]manifest.Project, len(projects))
+	for _, p := range projects {
+		byName[p.Name] = p
+	}
+
+	out := make([]manifest.Project, 0, len(names))
+	for _, name := range names {
+		p, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("project %q not found (or excluded by --groups %q)", name, groupsSpec)
+		}
+		out = append(out, p)
+	}
+
+	return out, nil
+}